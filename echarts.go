@@ -4,6 +4,18 @@
 // It defines two methods to display [go-echarts](https://github.com/go-echarts/go-echarts) charts: `Display`
 // that immediately display the chart, and `DisplayContent` that returns the HTML content needed to generate
 // the chart -- useful for instance if the chart needs to be laid out inside other HTML content.
+// `Display`/`DisplayContent` are generic over `SupportedCharts` for source compatibility, but simply
+// delegate to `DisplayRenderer`/`DisplayRendererContent`, which accept any chart implementing the
+// upstream `render.Renderer` interface and so don't need updating whenever go-echarts adds a chart type.
+//
+// It also supports `components.Page` multi-chart layouts through `DisplayPage`/`DisplayPageContent`, so a
+// whole grid of charts can be rendered from a single notebook cell.
+//
+// `OnEvent` lets a Go handler subscribe to ECharts events (click, mouseover, datazoom, ...) firing in
+// the browser, delivered back to the running kernel over GoNB's comm channel.
+//
+// For composing charts inside a caller's own `html/template` -- e.g. from a plain `net/http` handler,
+// not just GoNB -- see `Snippet` and `RenderSnippets`.
 //
 // See include `examples.ipynb` for examples.
 package echarts
@@ -13,6 +25,7 @@ import (
 	"fmt"
 	"github.com/PuerkitoBio/goquery"
 	"github.com/go-echarts/go-echarts/v2/charts"
+	"github.com/go-echarts/go-echarts/v2/components"
 	"github.com/janpfeifer/gonb/gonbui"
 	"github.com/pkg/errors"
 	"io"
@@ -29,24 +42,67 @@ type Renderer interface {
 
 // renderData parsed from go-echarts rendering, and re-used for GoNB rendering.
 type renderData struct {
-	// ChartId should be used by the container div that will hold the chart.
+	// ChartId should be used by the container div that will hold the chart, when ContainerHTML
+	// is not set.
 	ChartId string
 
+	// ContainerHTML, when set (e.g. when [renderSnippeter] is used), is the chart's container
+	// element already rendered by go-echarts, to use as-is instead of ChartId.
+	ContainerHTML string
+
 	// Script sources.
 	JsAssetsSrc []string
 
 	// JsAssetsCode code for the specific chart
 	JsAssetsCode []string
+
+	// EventsJS holds the JS that forwards ChartId's events (subscribed with OnEvent) to the Go
+	// kernel. It's empty if no event is subscribed.
+	EventsJS string
 }
 
-// parseRendering renders given the chart and extract the information needed to re-render it in GoNB.
+// extractJSAssets scans all the `<script>` tags of a rendered go-echarts page (or chart) and splits
+// them into the shared script sources (`<script src="...">`, e.g. `echarts.min.js`) and the inline
+// per-chart initialization code. Sources are deduplicated, preserving the order they were first seen.
+func extractJSAssets(doc *goquery.Document) (jsAssetsSrc, jsAssetsCode []string) {
+	seenSrc := make(map[string]bool)
+	doc.Find("script").Each(func(i int, selection *goquery.Selection) {
+		src, exists := selection.Attr("src")
+		if !exists {
+			jsCode := selection.Text()
+			if jsCode != "" {
+				jsAssetsCode = append(jsAssetsCode, jsCode)
+			}
+		} else if !seenSrc[src] {
+			seenSrc[src] = true
+			jsAssetsSrc = append(jsAssetsSrc, src)
+		}
+	})
+	return
+}
+
+// renderSnippeter will be implemented by go-echarts chart/page types once the upstream `RenderSnippet`
+// API lands (go-echarts PR #410): it returns the chart's container element plus its script sources and
+// inline code directly, so we no longer need to render a whole standalone HTML page just to scrape it
+// back with goquery. parseRendering prefers this path and only falls back to HTML scraping -- the
+// `Render` method every [Renderer] already provides -- for go-echarts versions that don't have it yet.
+type renderSnippeter interface {
+	RenderSnippet() (element string, jsAssetsSrc, jsAssetsCode []string)
+}
+
+// parseRendering renders the given [Renderer] (any go-echarts chart, or a [components.Page]) and
+// extracts the information needed to re-render it in GoNB.
 //
-// This is implemented by rendering it to an HTML page (with `<head>` and `<body>` tags) that is then
-// parsed
-func parseRendering(chart *charts.BaseConfiguration) (data renderData, err error) {
-	data.ChartId = chart.ChartID
+// When r implements [renderSnippeter], its snippet is used directly. Otherwise, it falls back to
+// rendering a full HTML page (with `<head>` and `<body>` tags) that is then parsed with goquery.
+func parseRendering(r Renderer) (data renderData, err error) {
+	if sr, ok := r.(renderSnippeter); ok {
+		data.ContainerHTML, data.JsAssetsSrc, data.JsAssetsCode = sr.RenderSnippet()
+		return
+	}
+
 	var buffer bytes.Buffer
-	err = chart.Render(&buffer)
+	err = r.Render(&buffer)
 	if err != nil {
 		err = errors.Wrapf(err, "failed to render chart to a page -- phase one of rendering to notebook")
 		return
@@ -59,20 +115,74 @@ func parseRendering(chart *charts.BaseConfiguration) (data renderData, err error
 		return
 	}
 
-	// Find javascript needed to run the chart.
-	var ()
-	doc.Find("script").Each(func(i int, selection *goquery.Selection) {
-		src, exists := selection.Attr("src")
-		if !exists {
-			jsCode := selection.Text()
-			if jsCode != "" {
-				data.JsAssetsCode = append(data.JsAssetsCode, jsCode)
-			}
-		} else {
-			data.JsAssetsSrc = append(data.JsAssetsSrc, src)
+	data.ChartId, _ = doc.Find("div.item[id]").First().Attr("id")
+	data.JsAssetsSrc, data.JsAssetsCode = extractJSAssets(doc)
+	data.EventsJS = eventsJS(data.ChartId)
+	return
+}
+
+// pageRenderData parsed from a go-echarts [components.Page] rendering, and re-used for GoNB rendering.
+type pageRenderData struct {
+	// PageId should be used by the container div that will hold the page.
+	PageId string
+
+	// ContainerHTML holds the page's own per-chart container `<div>`s (and any layout `<style>` tag),
+	// exactly as go-echarts laid them out -- with their `<script>` tags stripped out, since those are
+	// re-assembled by displayTmpl instead.
+	ContainerHTML string
+
+	// Script sources, deduplicated across all the charts in the page.
+	JsAssetsSrc []string
+
+	// JsAssetsCode, one entry per chart in the page.
+	JsAssetsCode []string
+
+	// EventsJS holds the JS that forwards the events (subscribed with OnEvent) of every chart in the
+	// page to the Go kernel. It's empty if no event is subscribed in any of the page's charts.
+	EventsJS string
+}
+
+// parsePageRendering renders the given page and extracts the information needed to re-render it in GoNB.
+//
+// Like [parseRendering], it renders to a full HTML page and parses it back, but it additionally keeps
+// the per-chart container `<div>`s (and the layout they are arranged in), since a page holds more than
+// one chart.
+func parsePageRendering(page *components.Page) (data pageRenderData, err error) {
+	var buffer bytes.Buffer
+	err = page.Render(&buffer)
+	if err != nil {
+		err = errors.Wrapf(err, "failed to render page -- phase one of rendering to notebook")
+		return
+	}
+	// page.ChartID is only generated during Render (through page.Validate), so it must be read back after.
+	data.PageId = page.ChartID
+
+	var doc *goquery.Document
+	doc, err = goquery.NewDocumentFromReader(&buffer)
+	if err != nil {
+		err = errors.Wrapf(err, "failed to parse rendered HTML")
+		return
+	}
+
+	data.JsAssetsSrc, data.JsAssetsCode = extractJSAssets(doc)
+
+	var chartIds []string
+	doc.Find("div.item[id]").Each(func(i int, selection *goquery.Selection) {
+		if id, exists := selection.Attr("id"); exists {
+			chartIds = append(chartIds, id)
 		}
 	})
-	_ = doc
+	for _, chartId := range chartIds {
+		data.EventsJS += eventsJS(chartId)
+	}
+
+	body := doc.Find("body").First()
+	body.Find("script").Remove()
+	data.ContainerHTML, err = body.Html()
+	if err != nil {
+		err = errors.Wrapf(err, "failed to extract the page's container HTML")
+		return
+	}
 	return
 }
 
@@ -102,6 +212,9 @@ var displayTmpl = template.Must(template.New("display").Parse(`
 	{{range .JsAssetsCode}}
 		{{.}}
 	{{end}}
+	{{if .EventsJS}}
+		{{.EventsJS}}
+	{{end}}
 	}
 
 	let echartsSrcs = [
@@ -179,8 +292,35 @@ var displayTmpl = template.Must(template.New("display").Parse(`
 // Display displays the EChart in GoNB.
 // The parameter `style` is used for the `<div>` tag that holds the plot. Typically, one will want to set the
 // `width` and `height`. E.g.: `style="width: 1024px; height:600px; background: white;"`.
+//
+// It is kept for source compatibility: it simply delegates to [DisplayRenderer], which works with any
+// chart type -- it doesn't need to know about SupportedCharts at all.
 func Display[T SupportedCharts](chart *T, style string) error {
-	html, err := DisplayContent(chart, style)
+	return DisplayRenderer(any(chart).(Renderer), style)
+}
+
+// DisplayContent returns the HTML content (including a `<script>` tag) that displays the EChart in GoNB.
+// One can used [Display] to display it directly, but if one wants to compose or change the layout, one can use
+// this instead.
+//
+// The parameter `style` is used for the `<div>` tag that holds the plot. Typically, one will want to set the
+// `width` and `height`. E.g.: `style="width: 1024px; height:600px; background: white;"`.
+//
+// It is kept for source compatibility: it simply delegates to [DisplayRendererContent], which works with
+// any chart type -- it doesn't need to know about SupportedCharts at all.
+func DisplayContent[T SupportedCharts](chart *T, style string) (html string, err error) {
+	return DisplayRendererContent(any(chart).(Renderer), style)
+}
+
+// DisplayRenderer displays in GoNB anything that implements the upstream go-echarts `render.Renderer`
+// interface -- which is every chart type in go-echarts, plus [components.Page]. Unlike [Display], it
+// doesn't need a type parameter restricted to [SupportedCharts], so it keeps working with chart types
+// added to go-echarts after this package was last updated.
+//
+// The parameter `style` is used for the `<div>` tag that holds the plot. Typically, one will want to set the
+// `width` and `height`. E.g.: `style="width: 1024px; height:600px; background: white;"`.
+func DisplayRenderer(r Renderer, style string) error {
+	html, err := DisplayRendererContent(r, style)
 	if err != nil {
 		return err
 	}
@@ -188,73 +328,23 @@ func Display[T SupportedCharts](chart *T, style string) error {
 	return nil
 }
 
-// DisplayContent returns the HTML content (including a `<script>` tag) that displays the EChart in GoNB.
-// One can used [Display] to display it directly, but if one wants to compose or change the layout, one can use
-// this instead.
+// DisplayRendererContent returns the HTML content (including a `<script>` tag) that displays r, anything
+// that implements the upstream go-echarts `render.Renderer` interface, in GoNB. One can use
+// [DisplayRenderer] to display it directly, but if one wants to compose or change the layout, one can
+// use this instead.
 //
 // The parameter `style` is used for the `<div>` tag that holds the plot. Typically, one will want to set the
 // `width` and `height`. E.g.: `style="width: 1024px; height:600px; background: white;"`.
-func DisplayContent[T SupportedCharts](chart *T, style string) (html string, err error) {
-	var data renderData
-	cAny := any(chart)
-	switch c := cAny.(type) {
-	case *charts.Bar:
-		data, err = parseRendering(&c.BaseConfiguration)
-	case *charts.Bar3D:
-		data, err = parseRendering(&c.BaseConfiguration)
-	case *charts.BoxPlot:
-		data, err = parseRendering(&c.BaseConfiguration)
-	case *charts.Custom:
-		data, err = parseRendering(&c.BaseConfiguration)
-	case *charts.EffectScatter:
-		data, err = parseRendering(&c.BaseConfiguration)
-	case *charts.Funnel:
-		data, err = parseRendering(&c.BaseConfiguration)
-	case *charts.Gauge:
-		data, err = parseRendering(&c.BaseConfiguration)
-	case *charts.Geo:
-		data, err = parseRendering(&c.BaseConfiguration)
-	case *charts.Graph:
-		data, err = parseRendering(&c.BaseConfiguration)
-	case *charts.HeatMap:
-		data, err = parseRendering(&c.BaseConfiguration)
-	case *charts.Kline:
-		data, err = parseRendering(&c.BaseConfiguration)
-	case *charts.Line3D:
-		data, err = parseRendering(&c.BaseConfiguration)
-	case *charts.Line:
-		data, err = parseRendering(&c.BaseConfiguration)
-	case *charts.Liquid:
-		data, err = parseRendering(&c.BaseConfiguration)
-	case *charts.Map:
-		data, err = parseRendering(&c.BaseConfiguration)
-	case *charts.Parallel:
-		data, err = parseRendering(&c.BaseConfiguration)
-	case *charts.Pie:
-		data, err = parseRendering(&c.BaseConfiguration)
-	case *charts.Radar:
-		data, err = parseRendering(&c.BaseConfiguration)
-	case *charts.Sankey:
-		data, err = parseRendering(&c.BaseConfiguration)
-	case *charts.Scatter3D:
-		data, err = parseRendering(&c.BaseConfiguration)
-	case *charts.Scatter:
-		data, err = parseRendering(&c.BaseConfiguration)
-	case *charts.Sunburst:
-		data, err = parseRendering(&c.BaseConfiguration)
-	case *charts.Surface3D:
-		data, err = parseRendering(&c.BaseConfiguration)
-	case *charts.ThemeRiver:
-		data, err = parseRendering(&c.BaseConfiguration)
-	case *charts.Tree:
-		data, err = parseRendering(&c.BaseConfiguration)
-	case *charts.TreeMap:
-		data, err = parseRendering(&c.BaseConfiguration)
-	case *charts.WordCloud:
-		data, err = parseRendering(&c.BaseConfiguration)
-	default:
-		err = errors.Errorf("unsupported EChart type %T", cAny)
+//
+// A [*components.Page] is special-cased to [DisplayPageContent], since a page holds several charts, each
+// with their own container `<div>` -- style is then only applied to the `<div>` wrapping the whole page,
+// same as [DisplayPageContent] itself.
+func DisplayRendererContent(r Renderer, style string) (html string, err error) {
+	if page, ok := r.(*components.Page); ok {
+		return DisplayPageContent(page, style)
 	}
+
+	data, err := parseRendering(r)
 	if err != nil {
 		return
 	}
@@ -272,6 +362,53 @@ func DisplayContent[T SupportedCharts](chart *T, style string) (html string, err
 	}
 
 	// Render HTML.
-	html = fmt.Sprintf(`<div id="%s" style="%s"></div><script>%s</script>`, data.ChartId, style, code.String())
+	if data.ContainerHTML != "" {
+		html = fmt.Sprintf(`<div style="%s">%s</div><script>%s</script>`, style, data.ContainerHTML, code.String())
+	} else {
+		html = fmt.Sprintf(`<div id="%s" style="%s"></div><script>%s</script>`, data.ChartId, style, code.String())
+	}
+	return
+}
+
+// DisplayPage displays a [components.Page] (a multi-chart layout) in GoNB.
+// The parameter `style` is used for the `<div>` tag that wraps the page -- since the page's own charts
+// already carry their individual sizes, it is typically only needed to set things like a `background`.
+func DisplayPage(page *components.Page, style string) error {
+	html, err := DisplayPageContent(page, style)
+	if err != nil {
+		return err
+	}
+	gonbui.DisplayHtml(html)
+	return nil
+}
+
+// DisplayPageContent returns the HTML content (including a `<script>` tag) that displays the
+// [components.Page] in GoNB, preserving its layout (`Flex`/`Center`/`None`) and initialization options.
+// One can use [DisplayPage] to display it directly, but if one wants to compose or change the outer
+// layout, one can use this instead.
+//
+// The parameter `style` is used for the `<div>` tag that wraps the page.
+func DisplayPageContent(page *components.Page, style string) (html string, err error) {
+	data, err := parsePageRendering(page)
+	if err != nil {
+		return
+	}
+	if len(data.JsAssetsSrc) == 0 || len(data.JsAssetsCode) == 0 {
+		err = errors.New("failed to parse javascript of go-echarts page rendering")
+		return
+	}
+
+	// Generate code: the same RequireJS-aware loader used for a single chart also stitches together
+	// the init scripts of every chart in the page, since it simply executes all of data.JsAssetsCode
+	// in order once the (deduplicated) data.JsAssetsSrc have loaded.
+	var code bytes.Buffer
+	err = displayTmpl.Execute(&code, &data)
+	if err != nil {
+		err = errors.Wrapf(err, "failed to executed template of javascript code to build the echarts page")
+		return
+	}
+
+	// Render HTML.
+	html = fmt.Sprintf(`<div id="%s" style="%s">%s</div><script>%s</script>`, data.PageId, style, data.ContainerHTML, code.String())
 	return
 }
@@ -0,0 +1,172 @@
+package echarts
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"sync"
+
+	"github.com/janpfeifer/gonb/gonbui/comms"
+)
+
+// EventPayload carries the ECharts event `params` object (https://echarts.apache.org/en/api.html#events)
+// delivered to a Go handler registered with [OnEvent], decoded from the JSON the front-end sent.
+type EventPayload map[string]any
+
+// EventHandler processes one ECharts event (e.g. "click", "mouseover", "datazoom",
+// "legendselectchanged") firing in the browser for a chart registered with [OnEvent].
+type EventHandler func(payload EventPayload) error
+
+// eventKey identifies the subscribers of one (chart, ECharts event name) pair.
+type eventKey struct {
+	ChartId, EventName string
+}
+
+// eventSubscriptionId identifies one call to OnEvent, so it can later be removed by its returned
+// unsubscribe function.
+type eventSubscriptionId int64
+
+type eventSubscription struct {
+	id      eventSubscriptionId
+	handler EventHandler
+}
+
+var (
+	muEvents       sync.Mutex
+	nextEventSubId eventSubscriptionId
+	eventHandlers  = make(map[eventKey][]eventSubscription)
+
+	// eventListeners holds the one comms.AddressChan used per chart, regardless of how many
+	// event names are subscribed on it.
+	eventListeners = make(map[string]*comms.AddressChan[string])
+
+	// eventListenerRefs counts, per address, how many live OnEvent subscriptions (across all event
+	// names of that chart) are keeping eventListeners[address] around. It's used to Close the
+	// listener -- and its dispatchEvents goroutine and comm subscription -- once the count hits zero.
+	eventListenerRefs = make(map[string]int)
+)
+
+// eventAddress is the comms address (see gonbui/comms) used to deliver every subscribed event of the
+// chart identified by chartId.
+func eventAddress(chartId string) string {
+	return "/gonb-echarts/events/" + chartId
+}
+
+// OnEvent registers handler to be called whenever eventName (e.g. "click", "mouseover", "datazoom",
+// "legendselectchanged", see https://echarts.apache.org/en/api.html#events) fires on the chart
+// identified by chartId -- the same id found in e.g. [ChartSnippet].ContainerID, or simply
+// `chart.ChartID` for a go-echarts chart.
+//
+// It generates the JS (in displayTmpl) that registers the equivalent `myChart.on(eventName, ...)`
+// listener in the browser the next time the chart is displayed, and forwards events to handler over
+// GoNB's comm channel -- the same mechanism GoNB widgets already use to round-trip messages between the
+// front-end and the running Go kernel. handler is called from its own goroutine.
+//
+// It returns an unsubscribe function that stops delivering events to handler.
+func OnEvent(chartId, eventName string, handler EventHandler) (unsubscribe func()) {
+	key := eventKey{ChartId: chartId, EventName: eventName}
+
+	muEvents.Lock()
+	id := nextEventSubId
+	nextEventSubId++
+	eventHandlers[key] = append(eventHandlers[key], eventSubscription{id: id, handler: handler})
+	address := eventAddress(chartId)
+	eventListenerRefs[address]++
+	if _, listening := eventListeners[address]; !listening {
+		listener := comms.Listen[string](address)
+		eventListeners[address] = listener
+		go dispatchEvents(chartId, listener)
+	}
+	muEvents.Unlock()
+
+	return func() { removeEventSubscription(key, id) }
+}
+
+// removeEventSubscription removes the subscription id from key's handlers, closing the underlying
+// comms listener for key.ChartId once its last subscription (across all its event names) is gone.
+func removeEventSubscription(key eventKey, id eventSubscriptionId) {
+	muEvents.Lock()
+	defer muEvents.Unlock()
+	subs := eventHandlers[key]
+	for i, sub := range subs {
+		if sub.id == id {
+			eventHandlers[key] = append(subs[:i], subs[i+1:]...)
+			break
+		}
+	}
+	if len(eventHandlers[key]) == 0 {
+		delete(eventHandlers, key)
+	}
+
+	address := eventAddress(key.ChartId)
+	eventListenerRefs[address]--
+	if eventListenerRefs[address] <= 0 {
+		delete(eventListenerRefs, address)
+		if listener, ok := eventListeners[address]; ok {
+			delete(eventListeners, address)
+			listener.Close()
+		}
+	}
+}
+
+// eventMessage is the JSON payload sent by the front-end (see eventsJS) for every subscribed ECharts
+// event.
+type eventMessage struct {
+	EventName string       `json:"eventName"`
+	Params    EventPayload `json:"params"`
+}
+
+// dispatchEvents decodes messages arriving on listener (one per chart) and invokes the handlers
+// registered, through OnEvent, for the corresponding (chartId, eventName).
+func dispatchEvents(chartId string, listener *comms.AddressChan[string]) {
+	for raw := range listener.C {
+		var msg eventMessage
+		if err := json.Unmarshal([]byte(raw), &msg); err != nil {
+			log.Printf("echarts: failed to decode event from chart %q: %+v", chartId, err)
+			continue
+		}
+
+		muEvents.Lock()
+		subs := append([]eventSubscription(nil), eventHandlers[eventKey{ChartId: chartId, EventName: msg.EventName}]...)
+		muEvents.Unlock()
+
+		for _, sub := range subs {
+			go func(handler EventHandler) {
+				if err := handler(msg.Params); err != nil {
+					log.Printf("echarts: OnEvent(%q, %q) handler returned an error: %+v", chartId, msg.EventName, err)
+				}
+			}(sub.handler)
+		}
+	}
+}
+
+// eventsJSTmpl registers one browser-side ECharts event listener that forwards the event to GoNB's
+// comm channel, using the same `gonb_comm` front-end object GoNB widgets use to synchronize state.
+const eventsJSTmpl = `
+	goecharts_%[1]s.on(%[2]q, function(params) {
+		let gonb_comm = globalThis?.gonb_comm;
+		if (!gonb_comm) { return; }
+		gonb_comm.newSyncedVariable(%[3]q, "").set(JSON.stringify({eventName: %[2]q, params: params}));
+	});`
+
+// eventsJS returns the JS needed to forward chartId's currently subscribed events (registered with
+// OnEvent) to the Go kernel. It returns "" if chartId has no event subscribed.
+func eventsJS(chartId string) string {
+	if chartId == "" {
+		return ""
+	}
+	muEvents.Lock()
+	var eventNames []string
+	for key := range eventHandlers {
+		if key.ChartId == chartId {
+			eventNames = append(eventNames, key.EventName)
+		}
+	}
+	muEvents.Unlock()
+
+	var js string
+	for _, eventName := range eventNames {
+		js += fmt.Sprintf(eventsJSTmpl, chartId, eventName, eventAddress(chartId))
+	}
+	return js
+}
@@ -0,0 +1,396 @@
+package echarts
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"os/exec"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/PuerkitoBio/goquery"
+	"github.com/chromedp/chromedp"
+	"github.com/janpfeifer/gonb/gonbui"
+	"github.com/pkg/errors"
+)
+
+// ImageFormat of the static image produced by [DisplayImage]/[DisplayImageContent].
+type ImageFormat string
+
+const (
+	// ImageFormatPNG renders to a raster PNG image, embedded as a base64-encoded `<img>`.
+	ImageFormatPNG ImageFormat = "png"
+	// ImageFormatJPEG renders to a raster JPEG image, embedded as a base64-encoded `<img>`.
+	ImageFormatJPEG ImageFormat = "jpeg"
+	// ImageFormatSVG renders to an SVG image, embedded inline.
+	ImageFormatSVG ImageFormat = "svg"
+)
+
+// ImageOptions configure how [DisplayImage]/[DisplayImageContent] render a chart to a static image.
+type ImageOptions struct {
+	// Width and Height of the rendered image, in pixels. If left as 0, [DisplayImageContent] falls back
+	// to the chart's own Initialization Width/Height (as set with e.g. `charts.WithInitializationOpts`),
+	// parsed from its rendered container `<div>` style -- this only understands plain pixel sizes
+	// (e.g. "900px"); other CSS units fall through and leave the dimension at 0.
+	//
+	// When both are set, [DisplayImageContent] also overrides the chart's container `<div>` size to
+	// match, since go-echarts otherwise hard-codes its Initialization size there, which a mere browser
+	// viewport (or jsdom window) resize wouldn't affect.
+	Width, Height int
+
+	// Format of the rendered image. Defaults to ImageFormatPNG.
+	Format ImageFormat
+
+	// DevicePixelRatio of the rendered image, as in ECharts' own `getDataURL` option. Defaults to 1.
+	DevicePixelRatio float64
+
+	// Timeout for the render to complete. Defaults to 10 seconds.
+	Timeout time.Duration
+
+	// Renderer used to rasterize the chart. Defaults to DefaultImageRenderer.
+	Renderer ImageRenderer
+}
+
+// withDefaults returns a copy of opts with its zero-valued fields filled in.
+func (opts ImageOptions) withDefaults() ImageOptions {
+	if opts.Format == "" {
+		opts.Format = ImageFormatPNG
+	}
+	if opts.DevicePixelRatio == 0 {
+		opts.DevicePixelRatio = 1
+	}
+	if opts.Timeout == 0 {
+		opts.Timeout = 10 * time.Second
+	}
+	if opts.Renderer == nil {
+		opts.Renderer = DefaultImageRenderer
+	}
+	return opts
+}
+
+// ImageRenderer renders a go-echarts chart page, as produced by a [Renderer]'s `Render` method, to a
+// static image. This is what makes charts usable in notebooks exported to non-JS contexts (nbconvert to
+// PDF/HTML without JS, GitHub's notebook preview, e-mail, static site generators), where ECharts can't
+// run in-browser.
+type ImageRenderer interface {
+	// RenderImage renders the chart page html to an image, per opts.
+	RenderImage(ctx context.Context, html string, opts ImageOptions) (data []byte, err error)
+}
+
+// DefaultImageRenderer is used by [DisplayImage]/[DisplayImageContent] when ImageOptions.Renderer is
+// nil. It drives a headless Chromium instance (through chromedp), which is cached across calls so
+// repeated renders in the same notebook process don't each pay the browser start-up cost.
+var DefaultImageRenderer ImageRenderer = &ChromedpImageRenderer{}
+
+// ChromedpImageRenderer is an [ImageRenderer] that loads the chart page in a headless Chromium instance
+// (via [chromedp]) and calls ECharts' own `getDataURL`/`renderToSVGString` to produce the image, exactly
+// as it would render in a real browser.
+//
+// The headless instance is started lazily, on the first call to RenderImage, and kept running for
+// subsequent calls. Call Close to shut it down.
+//
+// [chromedp]: https://github.com/chromedp/chromedp
+type ChromedpImageRenderer struct {
+	initOnce   sync.Once
+	initErr    error
+	allocCtx   context.Context
+	allocClose context.CancelFunc
+}
+
+// ensureStarted lazily starts (once) the headless Chromium instance shared by every RenderImage call:
+// each call then only opens (and closes) its own tab in it.
+func (r *ChromedpImageRenderer) ensureStarted() error {
+	r.initOnce.Do(func() {
+		execCtx, execClose := chromedp.NewExecAllocator(context.Background(), chromedp.DefaultExecAllocatorOptions[:]...)
+		browserCtx, browserClose := chromedp.NewContext(execCtx)
+		r.allocCtx = browserCtx
+		r.allocClose = func() {
+			browserClose()
+			execClose()
+		}
+		r.initErr = chromedp.Run(browserCtx)
+	})
+	return r.initErr
+}
+
+// Close shuts down the cached headless Chromium instance, if one was started.
+func (r *ChromedpImageRenderer) Close() {
+	if r.allocClose != nil {
+		r.allocClose()
+	}
+}
+
+// chromedpGetImageJS evaluates to the chart's rendered image, as a data URL: it looks up the ECharts
+// instance bound to the page's chart container -- `echarts.getInstanceByDom` -- so it works regardless
+// of the (auto-generated) global variable name go-echarts used to initialize it.
+//
+// go-echarts always initializes the chart with the (default) canvas renderer, which doesn't implement
+// `renderToSVGString` -- that method only exists on an instance initialized with `renderer: "svg"`. So
+// for SVG output, the chart's current option is lifted off the canvas instance and replayed onto a fresh
+// SVG-renderer instance over the same container before calling renderToSVGString.
+const chromedpGetImageJS = `(() => {
+	const dom = document.querySelector(".item") || document.querySelector("[_echarts_instance_]");
+	let chart = echarts.getInstanceByDom(dom);
+	if (%[2]s) {
+		const option = chart.getOption();
+		chart.dispose();
+		chart = echarts.init(dom, null, {renderer: "svg"});
+		chart.setOption(option);
+		return chart.renderToSVGString();
+	}
+	return chart.getDataURL({type: %[1]q, pixelRatio: %[3]v});
+})()`
+
+// RenderImage implements ImageRenderer.
+func (r *ChromedpImageRenderer) RenderImage(ctx context.Context, html string, opts ImageOptions) (data []byte, err error) {
+	if err = r.ensureStarted(); err != nil {
+		err = errors.Wrapf(err, "failed to start headless Chromium instance")
+		return
+	}
+
+	taskCtx, cancel := chromedp.NewContext(r.allocCtx)
+	defer cancel()
+	taskCtx, cancelTimeout := context.WithTimeout(taskCtx, opts.Timeout)
+	defer cancelTimeout()
+
+	dataURL := fmt.Sprintf("data:text/html;base64,%s", base64.StdEncoding.EncodeToString([]byte(html)))
+	isSVG := opts.Format == ImageFormatSVG
+	js := fmt.Sprintf(chromedpGetImageJS, string(opts.Format), fmt.Sprintf("%v", isSVG), opts.DevicePixelRatio)
+
+	var result string
+	actions := []chromedp.Action{chromedp.Navigate(dataURL)}
+	if opts.Width > 0 && opts.Height > 0 {
+		actions = append(actions, chromedp.EmulateViewport(int64(opts.Width), int64(opts.Height)))
+	}
+	actions = append(actions, chromedp.Evaluate(js, &result))
+	if err = chromedp.Run(taskCtx, actions...); err != nil {
+		err = errors.Wrapf(err, "failed to render chart to %s in headless Chromium", opts.Format)
+		return
+	}
+
+	if isSVG {
+		return []byte(result), nil
+	}
+	return decodeDataURL(result)
+}
+
+// decodeDataURL decodes the base64 payload of a `data:...;base64,...` URL, as returned by ECharts'
+// `getDataURL`.
+func decodeDataURL(dataURL string) ([]byte, error) {
+	_, payload, found := strings.Cut(dataURL, ",")
+	if !found {
+		return nil, errors.Errorf("malformed data URL returned by the chart renderer")
+	}
+	data, err := base64.StdEncoding.DecodeString(payload)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to decode data URL returned by the chart renderer")
+	}
+	return data, nil
+}
+
+// nodeRenderScript is a small Node.js bootstrap that renders the chart using the `jsdom` npm package --
+// installed separately, this package doesn't vendor it -- for environments without a browser available.
+// ECharts itself is not required from npm: jsdom loads it through the chart page's own `<script>` tags. It reads a JSON request ({html, width, height, format, devicePixelRatio}) from
+// stdin and writes the resulting data URL (or raw SVG markup) to stdout.
+const nodeRenderScript = `
+const { JSDOM } = require("jsdom");
+let request = "";
+process.stdin.on("data", chunk => { request += chunk; });
+process.stdin.on("end", () => {
+	const req = JSON.parse(request);
+	const dom = new JSDOM(req.html, {runScripts: "dangerously", resources: "usable", pretendToBeVisual: true});
+	dom.window.onload = () => {
+		const echarts = dom.window.echarts;
+		const domEl = dom.window.document.querySelector(".item") || dom.window.document.querySelector("[_echarts_instance_]");
+		let chart = echarts.getInstanceByDom(domEl);
+		let out;
+		if (req.format === "svg") {
+			// The chart is always initialized with the canvas renderer, which has no renderToSVGString --
+			// replay its option onto a fresh SVG-renderer instance over the same container.
+			const option = chart.getOption();
+			chart.dispose();
+			chart = echarts.init(domEl, null, {renderer: "svg"});
+			chart.setOption(option);
+			out = chart.renderToSVGString();
+		} else {
+			out = chart.getDataURL({type: req.format, pixelRatio: req.devicePixelRatio});
+		}
+		process.stdout.write(out);
+	};
+});
+`
+
+// NodeImageRenderer is an [ImageRenderer] that shells out to a Node.js process running `echarts` and
+// `jsdom` over stdin/stdout, for environments without a browser available.
+type NodeImageRenderer struct {
+	// NodePath is the `node` executable to invoke. Defaults to "node" (looked up in PATH).
+	NodePath string
+}
+
+// RenderImage implements ImageRenderer.
+func (r *NodeImageRenderer) RenderImage(ctx context.Context, html string, opts ImageOptions) (data []byte, err error) {
+	nodePath := r.NodePath
+	if nodePath == "" {
+		nodePath = "node"
+	}
+
+	request, err := json.Marshal(map[string]any{
+		"html":             html,
+		"width":            opts.Width,
+		"height":           opts.Height,
+		"format":           string(opts.Format),
+		"devicePixelRatio": opts.DevicePixelRatio,
+	})
+	if err != nil {
+		err = errors.Wrapf(err, "failed to marshal render request for the node renderer")
+		return
+	}
+
+	cmd := exec.CommandContext(ctx, nodePath, "-e", nodeRenderScript)
+	cmd.Stdin = bytes.NewReader(request)
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err = cmd.Run(); err != nil {
+		err = errors.Wrapf(err, "node renderer failed: %s", stderr.String())
+		return
+	}
+
+	if opts.Format == ImageFormatSVG {
+		return stdout.Bytes(), nil
+	}
+	return decodeDataURL(stdout.String())
+}
+
+// pixelSizeRE extracts the numeric pixel value out of a CSS `width`/`height` declaration, e.g.
+// "900px" out of `style="width:900px;height:500px;"`.
+var pixelSizeRE = regexp.MustCompile(`([0-9]+)px`)
+
+// parsePixelSize returns the pixel value of prop (e.g. "width") in the inline CSS style, or 0 if prop
+// isn't set in style or isn't a plain pixel size.
+func parsePixelSize(style, prop string) int {
+	propRE := regexp.MustCompile(prop + `\s*:\s*` + pixelSizeRE.String())
+	m := propRE.FindStringSubmatch(style)
+	if m == nil {
+		return 0
+	}
+	size, err := strconv.Atoi(m[1])
+	if err != nil {
+		return 0
+	}
+	return size
+}
+
+// parseInitializationSize parses the chart's own container `<div>` -- as rendered with its
+// `charts.WithInitializationOpts` Width/Height -- to recover the size ECharts was initialized with, for
+// [DisplayImageContent] to fall back to when ImageOptions.Width/Height are left at 0.
+func parseInitializationSize(html string) (width, height int) {
+	doc, err := goquery.NewDocumentFromReader(strings.NewReader(html))
+	if err != nil {
+		return 0, 0
+	}
+	style, _ := doc.Find("div.item[id]").First().Attr("style")
+	return parsePixelSize(style, "width"), parsePixelSize(style, "height")
+}
+
+// setPixelSize returns style with prop (e.g. "width") set to size px, replacing any existing
+// declaration for prop or appending a new one.
+func setPixelSize(style, prop string, size int) string {
+	propRE := regexp.MustCompile(prop + `\s*:\s*[0-9]+px`)
+	decl := fmt.Sprintf("%s:%dpx", prop, size)
+	if propRE.MatchString(style) {
+		return propRE.ReplaceAllString(style, decl)
+	}
+	if style != "" && !strings.HasSuffix(strings.TrimSpace(style), ";") {
+		style += ";"
+	}
+	return style + decl + ";"
+}
+
+// rewriteContainerSize overrides the width/height of the chart's rendered container `<div>` to match
+// width/height, since go-echarts hard-codes its Initialization size there -- a plain browser viewport
+// (chromedp's EmulateViewport) or jsdom window resize doesn't reach into a fixed-px div's own layout. It
+// returns html unchanged if either dimension is 0, or if the container can't be found/parsed.
+func rewriteContainerSize(html string, width, height int) string {
+	if width <= 0 || height <= 0 {
+		return html
+	}
+	doc, err := goquery.NewDocumentFromReader(strings.NewReader(html))
+	if err != nil {
+		return html
+	}
+	container := doc.Find("div.item[id]").First()
+	if container.Length() == 0 {
+		return html
+	}
+	style, _ := container.Attr("style")
+	style = setPixelSize(style, "width", width)
+	style = setPixelSize(style, "height", height)
+	container.SetAttr("style", style)
+	out, err := doc.Html()
+	if err != nil {
+		return html
+	}
+	return out
+}
+
+// DisplayImage renders the chart to a static image server-side (see [ImageOptions] and [ImageRenderer])
+// and displays it in GoNB, using [gonbui.DisplayPng] or [gonbui.DisplaySvg] depending on opts.Format.
+// This is useful for notebooks exported to non-JS contexts (nbconvert to PDF/HTML without JS, GitHub's
+// notebook preview, e-mail, static site generators), where the live JS output of [Display] would
+// otherwise just show as a blank `<div>`.
+func DisplayImage[T SupportedCharts](chart *T, opts ImageOptions) error {
+	opts = opts.withDefaults()
+	data, err := DisplayImageContent(chart, opts)
+	if err != nil {
+		return err
+	}
+	if opts.Format == ImageFormatSVG {
+		gonbui.DisplaySvg(string(data))
+	} else {
+		gonbui.DisplayPng(data)
+	}
+	return nil
+}
+
+// DisplayImageContent renders the chart to a static image server-side (see [ImageOptions] and
+// [ImageRenderer]) and returns the raw image bytes (or, for ImageFormatSVG, the SVG markup). One can use
+// [DisplayImage] to display it directly, but if one wants to further embed or post-process the image,
+// one can use this instead.
+func DisplayImageContent[T SupportedCharts](chart *T, opts ImageOptions) (data []byte, err error) {
+	r, ok := any(chart).(Renderer)
+	if !ok {
+		err = errors.Errorf("chart of type %T does not implement the Renderer interface", chart)
+		return
+	}
+
+	var buffer bytes.Buffer
+	if err = r.Render(&buffer); err != nil {
+		err = errors.Wrapf(err, "failed to render chart to a page -- phase one of rendering to an image")
+		return
+	}
+
+	opts = opts.withDefaults()
+	html := buffer.String()
+	if opts.Width == 0 || opts.Height == 0 {
+		if width, height := parseInitializationSize(html); width > 0 || height > 0 {
+			if opts.Width == 0 {
+				opts.Width = width
+			}
+			if opts.Height == 0 {
+				opts.Height = height
+			}
+		}
+	}
+	html = rewriteContainerSize(html, opts.Width, opts.Height)
+
+	ctx, cancel := context.WithTimeout(context.Background(), opts.Timeout)
+	defer cancel()
+	data, err = opts.Renderer.RenderImage(ctx, html, opts)
+	return
+}
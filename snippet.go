@@ -0,0 +1,113 @@
+package echarts
+
+import (
+	"bytes"
+	"fmt"
+	"html/template"
+	"io"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// ChartSnippet is a structured alternative to [DisplayContent]'s monolithic `<div>+<script>` string,
+// meant for composing several charts (or mixing charts with tables/markdown) inside a caller's own
+// `html/template`, e.g. from a plain `net/http` handler -- not just GoNB.
+type ChartSnippet struct {
+	// ContainerHTML is the chart's container `<div>`, to place anywhere in the caller's own template.
+	ContainerHTML template.HTML
+
+	// ContainerID is ContainerHTML's `<div>` id.
+	ContainerID string
+
+	// ScriptHTML is a standalone `<script>` tag that runs everything needed to display this chart on
+	// its own -- the same `<script>` tag that [DisplayContent] returns after the container `<div>`. It's handy when only one chart is being
+	// embedded; when embedding several, prefer [RenderSnippets] instead, which shares one script
+	// loader (and avoids N copies of `echarts.min.js`) across every chart's JSAssetSrcs.
+	ScriptHTML template.HTML
+
+	// JSAssetSrcs are the script sources needed to run this chart (e.g. `echarts.min.js`).
+	JSAssetSrcs []string
+
+	// JSInitCode is the JS that initializes this chart, once JSAssetSrcs have loaded.
+	JSInitCode string
+
+	// EventsJS is the JS (if any) that registers this chart's [OnEvent] listeners -- it must be
+	// included alongside JSInitCode wherever it runs, so [RenderSnippets] carries it forward; a
+	// caller executing JSInitCode by some other means must do the same.
+	EventsJS string
+}
+
+// Snippet renders chart and returns it as a [ChartSnippet], so the caller can place the container
+// `<div>` anywhere in their own template and collect script tags/asset URLs into a shared `<head>`,
+// deduplicated across multiple charts with [RenderSnippets].
+//
+// style is the container `<div>`'s CSS, e.g. `"width:600px;height:400px;"` -- ECharts needs an
+// explicit size to render into, the same as [DisplayContent] and [DisplayPageContent] require.
+func Snippet[T SupportedCharts](chart *T, style string) (snippet ChartSnippet, err error) {
+	r, ok := any(chart).(Renderer)
+	if !ok {
+		err = errors.Errorf("chart of type %T does not implement the Renderer interface", chart)
+		return
+	}
+
+	data, err := parseRendering(r)
+	if err != nil {
+		return
+	}
+	if len(data.JsAssetsSrc) == 0 || len(data.JsAssetsCode) == 0 {
+		err = errors.New("failed to parse javascript of go-echarts rendering")
+		return
+	}
+
+	var code bytes.Buffer
+	if err = displayTmpl.Execute(&code, &data); err != nil {
+		err = errors.Wrapf(err, "failed to executed template of javascript code to build the echart")
+		return
+	}
+
+	snippet.ContainerID = data.ChartId
+	if data.ContainerHTML != "" {
+		snippet.ContainerHTML = template.HTML(fmt.Sprintf(`<div style="%s">%s</div>`, style, data.ContainerHTML))
+	} else {
+		snippet.ContainerHTML = template.HTML(fmt.Sprintf(`<div id="%s" style="%s"></div>`, data.ChartId, style))
+	}
+	snippet.ScriptHTML = template.HTML(fmt.Sprintf(`<script>%s</script>`, code.String()))
+	snippet.JSAssetSrcs = data.JsAssetsSrc
+	snippet.JSInitCode = strings.Join(data.JsAssetsCode, "\n\n")
+	snippet.EventsJS = data.EventsJS
+	return
+}
+
+// RenderSnippets writes to w a single `<script>` tag that loads the union of every snippet's
+// JSAssetSrcs -- deduplicated, preserving the order they were first seen, reusing the same
+// RequireJS-aware loader logic as [DisplayContent] -- followed by each snippet's JSInitCode, in order,
+// and each snippet's EventsJS (so charts registered with [OnEvent] keep their listeners). This avoids
+// N copies of `echarts.min.js` when embedding many charts in the same page.
+//
+// It only writes the shared script tag: each snippet's ContainerHTML must still be placed in the page
+// by the caller, wherever their layout calls for it.
+func RenderSnippets(w io.Writer, snippets ...ChartSnippet) error {
+	var data renderData
+	seenSrc := make(map[string]bool)
+	for _, snippet := range snippets {
+		for _, src := range snippet.JSAssetSrcs {
+			if !seenSrc[src] {
+				seenSrc[src] = true
+				data.JsAssetsSrc = append(data.JsAssetsSrc, src)
+			}
+		}
+		if snippet.JSInitCode != "" {
+			data.JsAssetsCode = append(data.JsAssetsCode, snippet.JSInitCode)
+		}
+		data.EventsJS += snippet.EventsJS
+	}
+
+	var code bytes.Buffer
+	if err := displayTmpl.Execute(&code, &data); err != nil {
+		return errors.Wrapf(err, "failed to executed template of javascript code to build the echarts snippets")
+	}
+
+	_, err := fmt.Fprintf(w, "<script>%s</script>", code.String())
+	return errors.Wrap(err, "failed to write echarts snippets script")
+}